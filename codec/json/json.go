@@ -0,0 +1,22 @@
+// Package json registers a service.Codec that (de)serializes event payloads
+// as JSON, matching the SDK's built-in default. Import it
+// for its side effect to let MESG_CODEC=json select it explicitly.
+package json
+
+import (
+	"encoding/json"
+
+	service "github.com/mesg-foundation/mesg-go"
+)
+
+func init() {
+	service.RegisterCodec(Codec{})
+}
+
+// Codec is the JSON service.Codec. It's exported so it can also be passed
+// directly to service.CodecOption.
+type Codec struct{}
+
+func (Codec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (Codec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (Codec) Name() string                               { return "json" }
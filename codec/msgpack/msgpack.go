@@ -0,0 +1,21 @@
+// Package msgpack registers a service.Codec that (de)serializes event
+// payloads as MessagePack. Import it for its side effect and
+// select it with MESG_CODEC=msgpack, or pass Codec{} directly to
+// service.CodecOption.
+package msgpack
+
+import (
+	"github.com/vmihailenco/msgpack"
+	service "github.com/mesg-foundation/mesg-go"
+)
+
+func init() {
+	service.RegisterCodec(Codec{})
+}
+
+// Codec is the MessagePack service.Codec.
+type Codec struct{}
+
+func (Codec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (Codec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+func (Codec) Name() string                               { return "msgpack" }
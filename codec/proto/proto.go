@@ -0,0 +1,37 @@
+// Package proto registers a service.Codec that (de)serializes event
+// payloads as protobuf binary messages. v must implement
+// proto.Message. Import it for its side effect and select it with
+// MESG_CODEC=proto, or pass Codec{} directly to service.CodecOption.
+package proto
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	service "github.com/mesg-foundation/mesg-go"
+)
+
+func init() {
+	service.RegisterCodec(Codec{})
+}
+
+// Codec is the protobuf service.Codec.
+type Codec struct{}
+
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("proto: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("proto: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func (Codec) Name() string { return "proto" }
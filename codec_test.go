@@ -0,0 +1,72 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+type codecTestPayload struct {
+	Foo string `json:"foo"`
+}
+
+func TestJSONCodec(t *testing.T) {
+	c := jsonCodec{}
+	if c.Name() != "json" {
+		t.Fatalf("Name() = %q, want %q", c.Name(), "json")
+	}
+	data, err := c.Marshal(codecTestPayload{Foo: "bar"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got codecTestPayload
+	if err := c.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Foo != "bar" {
+		t.Fatalf("got %+v, want Foo=bar", got)
+	}
+}
+
+type fakeCodec struct{ name string }
+
+func (fakeCodec) Marshal(v interface{}) ([]byte, error)      { return nil, nil }
+func (fakeCodec) Unmarshal(data []byte, v interface{}) error { return nil }
+func (c fakeCodec) Name() string                              { return c.name }
+
+func TestCodecRegistry(t *testing.T) {
+	name := "fake-codec-for-test"
+	RegisterCodec(fakeCodec{name: name})
+
+	got, err := codecByName(name)
+	if err != nil {
+		t.Fatalf("codecByName(%q) error = %v", name, err)
+	}
+	if got.Name() != name {
+		t.Fatalf("codecByName(%q).Name() = %q", name, got.Name())
+	}
+
+	if _, err := codecByName("does-not-exist"); err == nil {
+		t.Fatal("codecByName(unknown) expected an error, got nil")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RegisterCodec(duplicate) expected a panic")
+		}
+	}()
+	RegisterCodec(fakeCodec{name: name})
+}
+
+func TestEmitWireData(t *testing.T) {
+	data := []byte("not valid utf-8: \xff\xfe")
+
+	if got := emitWireData(jsonCodec{}, data); got != string(data) {
+		t.Fatalf("emitWireData(json, %q) = %q, want unchanged", data, got)
+	}
+
+	binCodec := fakeCodec{name: "proto"}
+	want := base64.StdEncoding.EncodeToString(data)
+	if got := emitWireData(binCodec, data); got != want {
+		t.Fatalf("emitWireData(proto, %q) = %q, want %q", data, got, want)
+	}
+}
@@ -0,0 +1,34 @@
+// Package hash decodes the base58, 32-byte digests MESG uses to identify
+// services, environments and runners.
+package hash
+
+import (
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// Size is the length in bytes of a decoded hash.
+const Size = 32
+
+// Hash is a decoded 32-byte digest.
+type Hash [Size]byte
+
+// Decode parses the base58-encoded digest s into a Hash.
+func Decode(s string) (Hash, error) {
+	var h Hash
+	b, err := base58.Decode(s)
+	if err != nil {
+		return h, fmt.Errorf("hash: %s", err)
+	}
+	if len(b) != Size {
+		return h, fmt.Errorf("hash: invalid length %d, want %d", len(b), Size)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// String returns the base58 encoding of h.
+func (h Hash) String() string {
+	return base58.Encode(h[:])
+}
@@ -0,0 +1,39 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/mr-tron/base58"
+)
+
+func TestDecodeRoundTrip(t *testing.T) {
+	var want Hash
+	for i := range want {
+		want[i] = byte(i)
+	}
+	got, err := Decode(want.String())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got != want {
+		t.Fatalf("Decode(%q) = %v, want %v", want.String(), got, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"invalid base58", "0OIl"},
+		{"wrong length", base58.Encode([]byte{1, 2, 3, 4, 5})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.in); err == nil {
+				t.Fatalf("Decode(%q) expected an error, got nil", tt.in)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"log"
+	"time"
+
+	service "github.com/mesg-foundation/mesg-go"
+)
+
+// Logging returns a TaskMiddleware that logs every task execution's key,
+// outcome and latency to logger.
+func Logging(logger *log.Logger) service.TaskMiddleware {
+	return func(next service.TaskHandlerFunc) service.TaskHandlerFunc {
+		return func(execution *service.Execution) error {
+			start := time.Now()
+			err := next(execution)
+			logger.Printf("task %q handled in %s, err=%v", execution.Key(), time.Since(start), err)
+			return err
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"time"
+
+	service "github.com/mesg-foundation/mesg-go"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	tasksReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mesg",
+		Subsystem: "service",
+		Name:      "tasks_received_total",
+		Help:      "Total number of tasks received, by task key.",
+	}, []string{"task"})
+
+	tasksSucceeded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mesg",
+		Subsystem: "service",
+		Name:      "tasks_succeeded_total",
+		Help:      "Total number of tasks that completed without error, by task key.",
+	}, []string{"task"})
+
+	tasksFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "mesg",
+		Subsystem: "service",
+		Name:      "tasks_failed_total",
+		Help:      "Total number of tasks that returned an error, by task key.",
+	}, []string{"task"})
+
+	taskDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "mesg",
+		Subsystem: "service",
+		Name:      "task_duration_seconds",
+		Help:      "Task execution latency in seconds, by task key.",
+	}, []string{"task"})
+)
+
+func init() {
+	prometheus.MustRegister(tasksReceived, tasksSucceeded, tasksFailed, taskDuration)
+}
+
+// Metrics returns a TaskMiddleware that exposes Prometheus counters for
+// tasks received, succeeded and failed, plus a histogram of execution
+// latency, all labeled by task key.
+func Metrics() service.TaskMiddleware {
+	return func(next service.TaskHandlerFunc) service.TaskHandlerFunc {
+		return func(execution *service.Execution) error {
+			key := execution.Key()
+			tasksReceived.WithLabelValues(key).Inc()
+
+			start := time.Now()
+			err := next(execution)
+			taskDuration.WithLabelValues(key).Observe(time.Since(start).Seconds())
+
+			if err != nil {
+				tasksFailed.WithLabelValues(key).Inc()
+			} else {
+				tasksSucceeded.WithLabelValues(key).Inc()
+			}
+			return err
+		}
+	}
+}
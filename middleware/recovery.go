@@ -0,0 +1,27 @@
+// Package middleware provides built-in service.TaskMiddleware implementations
+// for panic recovery, timeouts, request logging and Prometheus metrics.
+package middleware
+
+import (
+	"fmt"
+	"log"
+
+	service "github.com/mesg-foundation/mesg-go"
+)
+
+// Recovery returns a TaskMiddleware that recovers from panics raised while
+// executing a task, logs them to logger and turns them into an error reply
+// instead of crashing the whole service.
+func Recovery(logger *log.Logger) service.TaskMiddleware {
+	return func(next service.TaskHandlerFunc) service.TaskHandlerFunc {
+		return func(execution *service.Execution) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("task panicked: %v", r)
+					logger.Printf("task %q recovered from panic: %v", execution.Key(), r)
+				}
+			}()
+			return next(execution)
+		}
+	}
+}
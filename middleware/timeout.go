@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	service "github.com/mesg-foundation/mesg-go"
+)
+
+// Timeout returns a TaskMiddleware that fails a task execution with
+// context.DeadlineExceeded if it hasn't returned within d.
+//
+// The underlying handler keeps running in the background after the timeout
+// fires; callers relying on Timeout to also cancel the handler should make
+// it context-aware.
+func Timeout(d time.Duration) service.TaskMiddleware {
+	return func(next service.TaskHandlerFunc) service.TaskHandlerFunc {
+		return func(execution *service.Execution) error {
+			errC := make(chan error, 1)
+			go func() { errC <- next(execution) }()
+			select {
+			case err := <-errC:
+				return err
+			case <-time.After(d):
+				return context.DeadlineExceeded
+			}
+		}
+	}
+}
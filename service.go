@@ -4,25 +4,52 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/mesg-foundation/core/protobuf/serviceapi"
+	"github.com/mesg-foundation/core/server/grpc/orchestrator"
+	"github.com/mesg-foundation/mesg-go/hash"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	endpointEnv = "MESG_ENDPOINT"
 	tokenEnv    = "MESG_TOKEN"
+	tlsCertEnv  = "MESG_TLS_CERT"
+	tlsKeyEnv   = "MESG_TLS_KEY"
+	tlsCAEnv    = "MESG_TLS_CA"
+	codecEnv    = "MESG_CODEC"
+
+	serviceHashEnv       = "MESG_SERVICE_HASH"
+	envHashEnv           = "MESG_ENV_HASH"
+	registerSignatureEnv = "MESG_REGISTER_SIGNATURE"
+
+	// runnerHashMetadataKey is the outgoing metadata key services created
+	// with NewRunner attach their runner hash under.
+	runnerHashMetadataKey = "mesg-runner-hash"
 )
 
 var (
@@ -32,8 +59,9 @@ var (
 
 // Service represents a MESG service.
 type Service struct {
-	// endpoint is the mesg-core endpoint.
-	endpoint string
+	// endpoints are the mesg-core endpoints this service balances requests
+	// across.
+	endpoints []string
 
 	// token is the service id.
 	token string
@@ -47,9 +75,20 @@ type Service struct {
 	// dialOptions holds dial options of gRPC.
 	dialOptions []grpc.DialOption
 
+	// tlsConfig, when non-nil, is used to dial mesg-core over TLS/mTLS
+	// instead of the insecure default.
+	tlsConfig *tls.Config
+
+	// tlsConfigErr carries a failure from MTLSOption until New can return it.
+	tlsConfigErr error
+
 	// callTimeout used to timeout gRPC requests or dial.
 	callTimeout time.Duration
 
+	// shutdownTimeout bounds how long Close waits for in-flight task
+	// executions to complete. Zero means wait indefinitely.
+	shutdownTimeout time.Duration
+
 	// cancel stops receiving from gRPC task stream.
 	cancel  context.CancelFunc
 	closing bool
@@ -66,49 +105,228 @@ type Service struct {
 	// taskables holds task handlers.
 	taskables []Taskable
 
+	// middlewares wrap every task execution, in FIFO order.
+	middlewares []TaskMiddleware
+
 	// log is a logger for service.
 	log *log.Logger
 
 	// logOutput is the output stream of log.
 	logOutput io.Writer
+
+	// codec marshals the event payloads sent by Emit. Task inputs and
+	// replies handled through Execution are not encoded with it.
+	codec Codec
+
+	// runnerHash is the base58-encoded runner hash obtained from the
+	// orchestrator by NewRunner. Empty for services created with New.
+	runnerHash string
+}
+
+// Codec marshals and unmarshals event payloads, letting users pick a
+// different wire format than the default JSON for events sent with Emit.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	Name() string
+}
+
+// jsonCodec is the default Codec, used when neither CodecOption nor
+// MESG_CODEC select another one.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return "json" }
+
+var (
+	codecsMu sync.Mutex
+	codecs   = map[string]Codec{}
+)
+
+// RegisterCodec makes c available by name, so MESG_CODEC=<name> can select
+// it without code changes. Codec subpackages (e.g. codec/proto) call this
+// from an init func; it panics if name is already registered.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	name := c.Name()
+	if _, ok := codecs[name]; ok {
+		panic("service: codec already registered: " + name)
+	}
+	codecs[name] = c
+}
+
+func codecByName(name string) (Codec, error) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("service: unknown codec %q, missing import?", name)
+	}
+	return c, nil
 }
 
 // Option is the configuration func of Service.
 type Option func(*Service)
 
+// TaskHandlerFunc handles the execution of a single task.
+type TaskHandlerFunc func(execution *Execution) error
+
+// TaskMiddleware wraps a TaskHandlerFunc with additional behavior, such as
+// recovery, timeouts, logging or metrics. See the service/middleware
+// subpackage for built-in middlewares.
+type TaskMiddleware func(next TaskHandlerFunc) TaskHandlerFunc
+
 // New starts a new Service with options.
 func New(options ...Option) (*Service, error) {
+	s, err := newService(options...)
+	if err != nil {
+		return nil, err
+	}
+	if s.token == "" {
+		return nil, errTokenNotSet
+	}
+	return s, s.setupServiceClient()
+}
+
+// newService builds and configures a Service common to both New and
+// NewRunner, stopping short of checking the token and dialing mesg-core.
+func newService(options ...Option) (*Service, error) {
 	// Keep alive prevents Docker network to drop TCP idle connections after 15 minutes.
 	// See: https://forum.mesg.com/t/solution-summary-for-docker-dropping-connections-after-15-min/246
 	dialKeepaliveOpt := grpc.WithKeepaliveParams(keepalive.ClientParameters{
 		Time: 5 * time.Minute, // 5 minutes because it's the minimun time of gRPC enforcement policy.
 	})
 	s := &Service{
-		endpoint:     os.Getenv(endpointEnv),
+		endpoints:    splitEndpoints(os.Getenv(endpointEnv)),
 		token:        os.Getenv(tokenEnv),
 		callTimeout:  time.Second * 10,
 		gracefulWait: &sync.WaitGroup{},
 		logOutput:    ioutil.Discard,
-		dialOptions:  []grpc.DialOption{dialKeepaliveOpt, grpc.WithInsecure()},
+		dialOptions:  []grpc.DialOption{dialKeepaliveOpt},
 	}
 	for _, option := range options {
 		option(s)
 	}
 	s.log = log.New(s.logOutput, "mesg", log.LstdFlags)
-	if s.endpoint == "" {
+	if s.tlsConfigErr != nil {
+		return nil, s.tlsConfigErr
+	}
+	if len(s.endpoints) == 0 {
 		return nil, errEndpointNotSet
 	}
-	if s.token == "" {
-		return nil, errTokenNotSet
+	if s.tlsConfig == nil {
+		tlsConfig, err := tlsConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		s.tlsConfig = tlsConfig
+	}
+	if s.tlsConfig != nil {
+		s.dialOptions = append(s.dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(s.tlsConfig)))
+	} else {
+		s.dialOptions = append(s.dialOptions, grpc.WithInsecure())
+	}
+	if s.codec == nil {
+		if name := os.Getenv(codecEnv); name != "" {
+			codec, err := codecByName(name)
+			if err != nil {
+				return nil, err
+			}
+			s.codec = codec
+		} else {
+			s.codec = jsonCodec{}
+		}
+	}
+	return s, nil
+}
+
+// NewRunner starts a new Service registered with the orchestrator using the
+// MESG_SERVICE_HASH, MESG_ENV_HASH and MESG_REGISTER_SIGNATURE runner
+// credentials instead of a static MESG_TOKEN. The runner hash obtained from
+// Register is attached to every outgoing request and is available through
+// RunnerHash. The legacy New constructor keeps working so services can
+// migrate incrementally.
+func NewRunner(options ...Option) (*Service, error) {
+	s, err := newService(options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.registerRunner(); err != nil {
+		return nil, err
 	}
 	return s, s.setupServiceClient()
 }
 
+// tlsConfigFromEnv builds a TLS config from MESG_TLS_CERT, MESG_TLS_KEY and
+// MESG_TLS_CA when they're set, so operators can enable TLS without code
+// changes. It returns a nil config when none of them are set.
+func tlsConfigFromEnv() (*tls.Config, error) {
+	certFile := os.Getenv(tlsCertEnv)
+	keyFile := os.Getenv(tlsKeyEnv)
+	caFile := os.Getenv(tlsCAEnv)
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+	return newTLSConfig(certFile, keyFile, caFile)
+}
+
+// newTLSConfig builds a *tls.Config from a client certificate/key pair and an
+// optional CA certificate used to verify the mesg-core server. certFile and
+// keyFile may be empty to get a config that only pins the CA.
+func newTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config := &tls.Config{}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		ca, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("could not parse CA certificate %q", caFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
 // EndpointOption receives the TCP endpoint of mesg-core.
+//
+// Deprecated: use EndpointsOption, which accepts more than one endpoint.
 func EndpointOption(address string) Option {
+	return EndpointsOption(address)
+}
+
+// EndpointsOption receives the TCP endpoints of mesg-core. When more than one
+// is given, requests are spread across them with a client-side round-robin
+// balancer so the service keeps working if one endpoint goes away.
+func EndpointsOption(addrs ...string) Option {
 	return func(s *Service) {
-		s.endpoint = address
+		s.endpoints = addrs
+	}
+}
+
+// splitEndpoints parses the comma-separated list of endpoints found in
+// MESG_ENDPOINT.
+func splitEndpoints(env string) []string {
+	if env == "" {
+		return nil
 	}
+	var endpoints []string
+	for _, addr := range strings.Split(env, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			endpoints = append(endpoints, addr)
+		}
+	}
+	return endpoints
 }
 
 // TokenOption receives token which is the unique id of this service.
@@ -125,6 +343,17 @@ func TimeoutOption(d time.Duration) Option {
 	}
 }
 
+// ShutdownTimeout bounds how long Close will wait for in-flight task
+// executions to complete before force-closing the underlying connection. If
+// it elapses, Close returns a wrapped context.DeadlineExceeded instead of
+// blocking forever, so orchestrators like Kubernetes or systemd don't hang on
+// a stuck task.
+func ShutdownTimeout(d time.Duration) Option {
+	return func(s *Service) {
+		s.shutdownTimeout = d
+	}
+}
+
 // LogOutputOption uses out as a log destination.
 func LogOutputOption(out io.Writer) Option {
 	return func(s *Service) {
@@ -132,6 +361,44 @@ func LogOutputOption(out io.Writer) Option {
 	}
 }
 
+// CodecOption marshals the event payloads passed to Emit using codec instead
+// of the default JSON encoding. Task inputs and replies are not encoded with
+// it.
+func CodecOption(codec Codec) Option {
+	return func(s *Service) {
+		s.codec = codec
+	}
+}
+
+// TLSOption enables TLS when dialing mesg-core using config.
+func TLSOption(config *tls.Config) Option {
+	return func(s *Service) {
+		s.tlsConfig = config
+	}
+}
+
+// MTLSOption enables mutual TLS when dialing mesg-core, using the client
+// certificate/key pair in certFile/keyFile and the CA certificate in caFile
+// to verify the mesg-core server.
+func MTLSOption(certFile, keyFile, caFile string) Option {
+	return func(s *Service) {
+		config, err := newTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			s.tlsConfigErr = err
+			return
+		}
+		s.tlsConfig = config
+	}
+}
+
+// MiddlewareOption appends mw to the chain of middlewares wrapping every
+// task execution, in the order given.
+func MiddlewareOption(mw ...TaskMiddleware) Option {
+	return func(s *Service) {
+		s.middlewares = append(s.middlewares, mw...)
+	}
+}
+
 // DialOption used to mock socket communication for unit testing.
 func DialOption(dialer Dialer) Option {
 	return func(s *Service) {
@@ -150,7 +417,12 @@ func (s *Service) setupServiceClient() error {
 	var err error
 	ctx, cancel := context.WithTimeout(context.Background(), s.callTimeout)
 	defer cancel()
-	s.conn, err = grpc.DialContext(ctx, s.endpoint, s.dialOptions...)
+	builder := newEndpointsResolverBuilder(s.endpoints)
+	dialOptions := append(s.dialOptions,
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
+	)
+	s.conn, err = grpc.DialContext(ctx, builder.Scheme()+":///mesg-core", dialOptions...)
 	if err != nil {
 		return err
 	}
@@ -158,6 +430,121 @@ func (s *Service) setupServiceClient() error {
 	return nil
 }
 
+// registerRunner decodes the runner credentials from the environment,
+// registers with the orchestrator to obtain a runner hash and token, and
+// arranges for the runner hash to be attached to every outgoing request.
+func (s *Service) registerRunner() error {
+	serviceHash, err := hash.Decode(os.Getenv(serviceHashEnv))
+	if err != nil {
+		return fmt.Errorf("%s: %s", serviceHashEnv, err)
+	}
+	envHash, err := hash.Decode(os.Getenv(envHashEnv))
+	if err != nil {
+		return fmt.Errorf("%s: %s", envHashEnv, err)
+	}
+	signature := os.Getenv(registerSignatureEnv)
+	if signature == "" {
+		return fmt.Errorf("%s is not set", registerSignatureEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.callTimeout)
+	defer cancel()
+	builder := newEndpointsResolverBuilder(s.endpoints)
+	dialOptions := append(append([]grpc.DialOption{}, s.dialOptions...),
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
+	)
+	conn, err := grpc.DialContext(ctx, builder.Scheme()+":///mesg-orchestrator", dialOptions...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := orchestrator.NewRunnerClient(conn).Register(ctx, &orchestrator.RegisterRequest{
+		ServiceHash: serviceHash[:],
+		EnvHash:     envHash[:],
+		Signature:   signature,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.runnerHash = resp.RunnerHash
+	s.token = resp.Token
+	s.dialOptions = append(s.dialOptions,
+		grpc.WithUnaryInterceptor(s.runnerUnaryInterceptor),
+		grpc.WithStreamInterceptor(s.runnerStreamInterceptor),
+	)
+	return nil
+}
+
+// runnerUnaryInterceptor attaches the runner hash to outgoing unary request
+// metadata so mesg-core can identify which runner is calling it.
+func (s *Service) runnerUnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(metadata.AppendToOutgoingContext(ctx, runnerHashMetadataKey, s.runnerHash), method, req, reply, cc, opts...)
+}
+
+// runnerStreamInterceptor attaches the runner hash to outgoing streaming
+// request metadata so mesg-core can identify which runner is calling it.
+func (s *Service) runnerStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(metadata.AppendToOutgoingContext(ctx, runnerHashMetadataKey, s.runnerHash), desc, cc, method, opts...)
+}
+
+// RunnerHash returns the base58-encoded runner hash obtained from the
+// orchestrator by NewRunner. It's empty for services created with New.
+func (s *Service) RunnerHash() string {
+	return s.runnerHash
+}
+
+// endpointsResolverCounter gives every Service its own resolver scheme so
+// that dialing several services in the same process doesn't clash.
+var endpointsResolverCounter uint64
+
+// endpointsResolverBuilder resolves to the fixed set of mesg-core endpoints
+// a Service was configured with, letting gRPC's round_robin balancer fan
+// requests out across all of them.
+type endpointsResolverBuilder struct {
+	scheme    string
+	addresses []resolver.Address
+}
+
+func newEndpointsResolverBuilder(endpoints []string) *endpointsResolverBuilder {
+	addresses := make([]resolver.Address, len(endpoints))
+	for i, endpoint := range endpoints {
+		addresses[i] = resolver.Address{Addr: endpoint, ServerName: addressHost(endpoint)}
+	}
+	return &endpointsResolverBuilder{
+		scheme:    fmt.Sprintf("mesg%d", atomic.AddUint64(&endpointsResolverCounter, 1)),
+		addresses: addresses,
+	}
+}
+
+// addressHost returns the host part of a host:port endpoint, so TLS/mTLS
+// verifies the server certificate against the real endpoint instead of the
+// synthetic "mesg-core"/"mesg-orchestrator" authority the static resolver
+// dials under. Falls back to endpoint unchanged if it has no port.
+func addressHost(endpoint string) string {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return host
+}
+
+func (b *endpointsResolverBuilder) Scheme() string { return b.scheme }
+
+func (b *endpointsResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	cc.UpdateState(resolver.State{Addresses: b.addresses})
+	return endpointsResolver{}, nil
+}
+
+// endpointsResolver is a no-op resolver.Resolver: the address list is fixed
+// at Build time and never changes.
+type endpointsResolver struct{}
+
+func (endpointsResolver) ResolveNow(resolver.ResolveNowOptions) {}
+func (endpointsResolver) Close()                                {}
+
 // Listen listens requests for given tasks. It's a blocking call.
 func (s *Service) Listen(task Taskable, tasks ...Taskable) error {
 	s.ml.Lock()
@@ -179,9 +566,41 @@ func (s *Service) Listen(task Taskable, tasks ...Taskable) error {
 // TODO(ilgooz) use validation handlers of core server to do this?
 func (s *Service) validateTasks() error { return nil }
 
+// listenTasks keeps (re)establishing the task stream for the lifetime of the
+// service. A dropped stream caused by a transient error (e.g. mesg-core
+// restarting) is retried with an exponential backoff instead of being
+// surfaced to the caller, so long-running services survive core restarts and
+// rolling upgrades.
 func (s *Service) listenTasks() error {
+	b := newBackoff()
+	for {
+		err := s.listenTasksOnce()
+		s.mc.Lock()
+		closing := s.closing
+		s.mc.Unlock()
+		if closing {
+			return nil
+		}
+		if err == nil {
+			return nil
+		}
+		if !isTransientErr(err) {
+			return err
+		}
+		d := b.next()
+		s.log.Printf("lost connection to mesg-core (%s), reconnecting in %s", err, d)
+		time.Sleep(d)
+	}
+}
+
+func (s *Service) listenTasksOnce() error {
 	var ctx context.Context
 	s.mc.Lock()
+	if s.cancel != nil {
+		// Cancel the previous attempt's context so its "wait for done"
+		// goroutine below doesn't leak across reconnects.
+		s.cancel()
+	}
 	ctx, s.cancel = context.WithCancel(context.Background())
 	s.mc.Unlock()
 	stream, err := s.client.ListenTask(ctx, &serviceapi.ListenTaskRequest{
@@ -191,7 +610,9 @@ func (s *Service) listenTasks() error {
 		return err
 	}
 
-	errC := make(chan error)
+	// Buffered so neither goroutine below blocks forever if the other one's
+	// send is the one listenTasksOnce ends up reading.
+	errC := make(chan error, 2)
 	go func() {
 		<-stream.Context().Done()
 		errC <- stream.Context().Err()
@@ -216,6 +637,42 @@ func (s *Service) listenTasks() error {
 	return <-errC
 }
 
+// isTransientErr reports whether err is likely to clear up on its own, such
+// as mesg-core restarting or a rolling upgrade briefly dropping the stream.
+func isTransientErr(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	backoffBase = 500 * time.Millisecond
+	backoffMax  = 30 * time.Second
+)
+
+// backoff computes a capped, jittered exponential backoff duration between
+// reconnect attempts.
+type backoff struct {
+	attempt uint
+}
+
+func newBackoff() *backoff {
+	return &backoff{}
+}
+
+func (b *backoff) next() time.Duration {
+	d := backoffBase << b.attempt
+	if d <= 0 || d > backoffMax {
+		d = backoffMax
+	} else {
+		b.attempt++
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)/2+1))
+}
+
 func (s *Service) getTaskableByName(key string) Taskable {
 	for _, taskable := range s.taskables {
 		if taskable.Key() == key {
@@ -233,14 +690,29 @@ func (s *Service) executeTask(data *serviceapi.TaskData) {
 		return
 	}
 	execution := newExecution(s, data)
-	if err := execution.reply(taskable.Execute(execution)); err != nil {
+	if err := execution.reply(s.taskHandler(taskable)(execution)); err != nil {
 		s.log.Println(err)
 	}
 }
 
-// Emit emits a MESG event eventKey with given eventData.
+// taskHandler wraps taskable.Execute with s.middlewares, in FIFO order, so
+// the first middleware passed to MiddlewareOption runs outermost.
+func (s *Service) taskHandler(taskable Taskable) TaskHandlerFunc {
+	handler := TaskHandlerFunc(taskable.Execute)
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		handler = s.middlewares[i](handler)
+	}
+	return handler
+}
+
+// Emit emits a MESG event eventKey with given eventData. eventData is
+// marshaled with the service's codec: the default json codec's output is
+// already valid UTF-8 and travels as-is, unchanged from mesg-core's
+// historical wire format, while binary codecs like proto or msgpack travel
+// base64-encoded since EventData is a proto3 string field that must be
+// valid UTF-8.
 func (s *Service) Emit(eventKey string, eventData interface{}) error {
-	dataBytes, err := json.Marshal(eventData)
+	dataBytes, err := s.codec.Marshal(eventData)
 	if err != nil {
 		return err
 	}
@@ -249,20 +721,80 @@ func (s *Service) Emit(eventKey string, eventData interface{}) error {
 	_, err = s.client.EmitEvent(ctx, &serviceapi.EmitEventRequest{
 		Token:     s.token,
 		EventKey:  eventKey,
-		EventData: string(dataBytes),
+		EventData: emitWireData(s.codec, dataBytes),
 	})
 	return err
 }
 
-// Close gracefully stops listening for future task execution requests and waits
-// current ones to complete before closing underlying connection.
+// emitWireData encodes a codec's marshaled output for EmitEventRequest's
+// EventData, a proto3 string field that must be valid UTF-8. The json
+// codec's output is already valid UTF-8 and travels as-is, unchanged from
+// mesg-core's historical wire format; binary codecs like proto or msgpack
+// travel base64-encoded instead.
+func emitWireData(codec Codec, data []byte) string {
+	if codec.Name() == (jsonCodec{}).Name() {
+		return string(data)
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// Close gracefully stops listening for future task execution requests and
+// waits for current ones to complete before closing the underlying
+// connection.
 func (s *Service) Close() error {
 	s.mc.Lock()
 	defer s.mc.Unlock()
 	s.closing = true
-	s.cancel()
-	s.gracefulWait.Wait()
-	return s.conn.Close()
+	if s.cancel != nil {
+		s.cancel()
+	}
+
+	if s.shutdownTimeout <= 0 {
+		s.gracefulWait.Wait()
+		return s.conn.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.gracefulWait.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return s.conn.Close()
+	case <-time.After(s.shutdownTimeout):
+		s.conn.Close()
+		return fmt.Errorf("service: shutdown timed out after %s: %w", s.shutdownTimeout, context.DeadlineExceeded)
+	}
+}
+
+// Run listens for tasks and blocks until ctx is done or the process receives
+// SIGINT, SIGTERM or SIGHUP. Either triggers Close, and Run returns the first
+// error encountered between listening and closing. This is the "correct"
+// shutdown pattern every user of the package otherwise has to reimplement.
+func (s *Service) Run(ctx context.Context, tasks ...Taskable) error {
+	if len(tasks) == 0 {
+		return errors.New("service: Run requires at least one task")
+	}
+
+	listenErrC := make(chan error, 1)
+	go func() { listenErrC <- s.Listen(tasks[0], tasks[1:]...) }()
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigC)
+
+	select {
+	case err := <-listenErrC:
+		return err
+	case <-ctx.Done():
+	case <-sigC:
+	}
+
+	if err := s.Close(); err != nil {
+		return err
+	}
+	return <-listenErrC
 }
 
 type errNonExistentTask struct {
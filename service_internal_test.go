@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSplitEndpoints(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"single", "127.0.0.1:50052", []string{"127.0.0.1:50052"}},
+		{"multiple", "a:1,b:2", []string{"a:1", "b:2"}},
+		{"spaces and blanks", " a:1 , , b:2 ", []string{"a:1", "b:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitEndpoints(tt.env)
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitEndpoints(%q) = %v, want %v", tt.env, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("splitEndpoints(%q) = %v, want %v", tt.env, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAddressHost(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"core.example.com:50052", "core.example.com"},
+		{"127.0.0.1:50052", "127.0.0.1"},
+		{"no-port", "no-port"},
+	}
+	for _, tt := range tests {
+		if got := addressHost(tt.endpoint); got != tt.want {
+			t.Errorf("addressHost(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestIsTransientErr(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "down"), true},
+		{"deadline exceeded", status.Error(codes.DeadlineExceeded, "timeout"), true},
+		{"permission denied", status.Error(codes.PermissionDenied, "nope"), false},
+		{"plain error", errors.New("boom"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := isTransientErr(tt.err); got != tt.want {
+			t.Errorf("isTransientErr(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffNext(t *testing.T) {
+	b := newBackoff()
+	// Backoff should stay within (0, backoffMax], including once the
+	// exponential growth has capped out, rather than overflowing.
+	for i := 0; i < 15; i++ {
+		if d := b.next(); d <= 0 || d > backoffMax {
+			t.Fatalf("next() = %s, want in (0, %s]", d, backoffMax)
+		}
+	}
+}
+
@@ -0,0 +1,59 @@
+package service
+
+import "testing"
+
+type fakeTaskable struct {
+	key string
+	run func(*Execution) error
+}
+
+func (f fakeTaskable) Key() string               { return f.key }
+func (f fakeTaskable) Execute(e *Execution) error { return f.run(e) }
+
+func namedMiddleware(order *[]string, name string) TaskMiddleware {
+	return func(next TaskHandlerFunc) TaskHandlerFunc {
+		return func(execution *Execution) error {
+			*order = append(*order, name)
+			return next(execution)
+		}
+	}
+}
+
+func TestTaskHandlerMiddlewareOrderIsFIFO(t *testing.T) {
+	var order []string
+	taskable := fakeTaskable{
+		key: "my-task",
+		run: func(*Execution) error {
+			order = append(order, "base")
+			return nil
+		},
+	}
+	s := &Service{
+		middlewares: []TaskMiddleware{
+			namedMiddleware(&order, "first"),
+			namedMiddleware(&order, "second"),
+		},
+	}
+
+	if err := s.taskHandler(taskable)(nil); err != nil {
+		t.Fatalf("taskHandler()() error = %v", err)
+	}
+
+	want := []string{"first", "second", "base"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestTaskHandlerNoMiddlewares(t *testing.T) {
+	s := &Service{}
+	taskable := fakeTaskable{key: "my-task", run: func(*Execution) error { return nil }}
+	if err := s.taskHandler(taskable)(nil); err != nil {
+		t.Fatalf("taskHandler()() error = %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestTLSConfigFromEnvUnset(t *testing.T) {
+	for _, key := range []string{tlsCertEnv, tlsKeyEnv, tlsCAEnv} {
+		os.Unsetenv(key)
+	}
+	config, err := tlsConfigFromEnv()
+	if err != nil {
+		t.Fatalf("tlsConfigFromEnv() error = %v", err)
+	}
+	if config != nil {
+		t.Fatalf("tlsConfigFromEnv() = %+v, want nil when no env vars are set", config)
+	}
+}
+
+func TestNewTLSConfigMissingCertFile(t *testing.T) {
+	if _, err := newTLSConfig("/does/not/exist.crt", "/does/not/exist.key", ""); err == nil {
+		t.Fatal("newTLSConfig() with a missing cert file expected an error, got nil")
+	}
+}
+
+func TestNewTLSConfigInvalidCA(t *testing.T) {
+	f, err := ioutil.TempFile("", "mesg-ca-*.pem")
+	if err != nil {
+		t.Fatalf("TempFile() error = %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString("not a certificate"); err != nil {
+		t.Fatalf("WriteString() error = %v", err)
+	}
+	f.Close()
+
+	if _, err := newTLSConfig("", "", f.Name()); err == nil {
+		t.Fatal("newTLSConfig() with an invalid CA file expected an error, got nil")
+	}
+}
+
+func TestNewTLSConfigNoFiles(t *testing.T) {
+	// With no cert, key or CA file, newTLSConfig returns an empty, non-nil
+	// config rather than an error.
+	config, err := newTLSConfig("", "", "")
+	if err != nil {
+		t.Fatalf("newTLSConfig(\"\", \"\", \"\") error = %v", err)
+	}
+	if len(config.Certificates) != 0 || config.RootCAs != nil {
+		t.Fatalf("newTLSConfig(\"\", \"\", \"\") = %+v, want an empty config", config)
+	}
+}